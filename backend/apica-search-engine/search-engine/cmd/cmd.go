@@ -17,6 +17,12 @@ const defaultConfig = ".search-engine"
 const (
 	configListenAddress = "listen-address"
 	configParquetPath   = "parquet-path"
+	configIndexDir      = "index-dir"
+	configIndexBackend  = "index-backend"
+	configBM25K1        = "bm25-k1"
+	configBM25B         = "bm25-b"
+	configLogFormat     = "log-format"
+	configLogLevel      = "log-level"
 )
 
 var myViper = viper.New()
@@ -25,15 +31,18 @@ func getConfig() *server.Config {
 	return &server.Config{
 		ListenAddress: myViper.GetString(configListenAddress),
 		ParquetPath:   myViper.GetString(configParquetPath),
+		IndexDir:      myViper.GetString(configIndexDir),
+		IndexBackend:  myViper.GetString(configIndexBackend),
+		BM25K1:        myViper.GetFloat64(configBM25K1),
+		BM25B:         myViper.GetFloat64(configBM25B),
+		LogFormat:     myViper.GetString(configLogFormat),
+		LogLevel:      myViper.GetString(configLogLevel),
 	}
 }
 
 // DefaultConfig returns default configuration values
 func DefaultConfig() *server.Config {
-	return &server.Config{
-		ListenAddress: ":8080",
-		ParquetPath:   "C:\\Projects\\the-mail\\apica-search-engine\\docs",
-	}
+	return server.DefaultConfig()
 }
 
 // RootCmd is the entrypoint for the application.
@@ -65,6 +74,10 @@ func stringFlag(flag string, def string, desc string) {
 	RootCmd.PersistentFlags().String(flag, def, desc)
 }
 
+func float64Flag(flag string, def float64, desc string) {
+	RootCmd.PersistentFlags().Float64(flag, def, desc)
+}
+
 var cfgPath string
 
 // Execute parses CLI options.
@@ -85,6 +98,10 @@ func Execute() {
 	}{
 		{configListenAddress, defaultConfig.ListenAddress, "Listen address"},
 		{configParquetPath, defaultConfig.ParquetPath, "Parquet path"},
+		{configIndexDir, defaultConfig.IndexDir, "Directory the on-disk search index is stored in"},
+		{configIndexBackend, defaultConfig.IndexBackend, "Search index backend (bolt)"},
+		{configLogFormat, defaultConfig.LogFormat, "Log format (json or text)"},
+		{configLogLevel, defaultConfig.LogLevel, "Log level (debug, info, warn, error)"},
 	}
 
 	for _, arg := range stringArgs {
@@ -92,6 +109,20 @@ func Execute() {
 		bindViper(arg.flag)
 	}
 
+	float64Args := []struct {
+		flag string
+		def  float64
+		desc string
+	}{
+		{configBM25K1, defaultConfig.BM25K1, "BM25 term frequency saturation parameter"},
+		{configBM25B, defaultConfig.BM25B, "BM25 document length normalization parameter"},
+	}
+
+	for _, arg := range float64Args {
+		float64Flag(arg.flag, arg.def, arg.desc)
+		bindViper(arg.flag)
+	}
+
 	if err := RootCmd.Execute(); err != nil {
 		log.Printf("command error: %v", err)
 		os.Exit(-1)
@@ -0,0 +1,68 @@
+package server
+
+import "regexp"
+
+// indexedFields are the Document fields that are tokenized and indexed,
+// both under their bare term and under a "field:term" qualified key.
+var indexedFields = []string{"message", "tag", "sender", "event"}
+
+// fieldValue returns the text of the named indexed field on doc, or "" if
+// field is not an indexed field.
+func fieldValue(doc *Document, field string) string {
+	switch field {
+	case "message":
+		return doc.Message
+	case "tag":
+		return doc.Tag
+	case "sender":
+		return doc.Sender
+	case "event":
+		return doc.Event
+	default:
+		return ""
+	}
+}
+
+// Highlight is a single matched-term hit, as a byte offset range
+// within one field of a Document.
+type Highlight struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// highlightsForDoc finds, for every positive clause of a query, where its
+// terms occur in doc's indexed fields (restricted to the clause's field
+// when it is field-qualified).
+func highlightsForDoc(doc *Document, clauses []queryClause) []Highlight {
+	var highlights []Highlight
+
+	for _, clause := range clauses {
+		fields := indexedFields
+		if clause.Field != "" {
+			fields = []string{clause.Field}
+		}
+
+		for _, term := range clause.Terms {
+			re := wordBoundaryPattern(term)
+			if re == nil {
+				continue
+			}
+			for _, field := range fields {
+				for _, loc := range re.FindAllStringIndex(fieldValue(doc, field), -1) {
+					highlights = append(highlights, Highlight{Field: field, Start: loc[0], End: loc[1]})
+				}
+			}
+		}
+	}
+
+	return highlights
+}
+
+func wordBoundaryPattern(term string) *regexp.Regexp {
+	re, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(term) + `\b`)
+	if err != nil {
+		return nil
+	}
+	return re
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is the structured logging interface used throughout server. It is
+// satisfied by a zap.SugaredLogger wrapper, but kept as an interface so
+// request-scoped loggers (carrying a request ID) and test doubles don't
+// need to depend on zap directly.
+type Logger interface {
+	Debugf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Warnf(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+	Fatalf(template string, args ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	// With returns a Logger that always includes keysAndValues in every
+	// entry it emits, e.g. a per-request logger carrying "request_id".
+	With(keysAndValues ...interface{}) Logger
+}
+
+// NewLogger builds a Logger. format selects the encoding ("json" or
+// "text"); level is a zap level name ("debug", "info", "warn", "error").
+func NewLogger(format, level string) (Logger, error) {
+	var lvl zapcore.Level
+	if level == "" {
+		level = "info"
+	}
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	var cfg zap.Config
+	switch format {
+	case "", "text":
+		cfg = zap.NewDevelopmentConfig()
+	case "json":
+		cfg = zap.NewProductionConfig()
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", format)
+	}
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+
+	zl, err := cfg.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logger: %w", err)
+	}
+
+	return &sugaredLogger{zl.Sugar()}, nil
+}
+
+// sugaredLogger adapts a zap.SugaredLogger to Logger.
+type sugaredLogger struct {
+	*zap.SugaredLogger
+}
+
+func (l *sugaredLogger) With(keysAndValues ...interface{}) Logger {
+	return &sugaredLogger{l.SugaredLogger.With(keysAndValues...)}
+}
+
+// noopLogger discards everything. It is the default Logger for a
+// SearchIndex created without one, so internal error logging is always
+// safe to call without a nil check.
+type noopLogger struct{}
+
+func (noopLogger) Debugf(string, ...interface{}) {}
+func (noopLogger) Infof(string, ...interface{})  {}
+func (noopLogger) Warnf(string, ...interface{})  {}
+func (noopLogger) Errorf(string, ...interface{}) {}
+func (noopLogger) Fatalf(string, ...interface{}) {}
+func (noopLogger) Infow(string, ...interface{})  {}
+func (noopLogger) Errorw(string, ...interface{}) {}
+func (noopLogger) With(...interface{}) Logger    { return noopLogger{} }
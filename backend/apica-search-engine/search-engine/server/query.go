@@ -0,0 +1,140 @@
+package server
+
+import (
+	"strings"
+	"unicode"
+)
+
+// queryClause is a single term or phrase extracted from a query string,
+// optionally restricted to a field (e.g. `sender:foo`) and optionally
+// negated (e.g. `-foo`). Terms has more than one entry only for phrase
+// clauses, where adjacency of the terms is required for a match.
+type queryClause struct {
+	Field  string
+	Terms  []string
+	Negate bool
+}
+
+// indexKey returns the InvertedIndex key a term of this clause is stored
+// under: the bare term for an unqualified clause, or "field:term" for a
+// field-qualified one.
+func (c queryClause) indexKey(term string) string {
+	if c.Field == "" {
+		return term
+	}
+	return c.Field + ":" + term
+}
+
+// parsedQuery is a query string split into positive clauses that must all
+// match (AND semantics) and negative clauses that must not match.
+type parsedQuery struct {
+	Must    []queryClause
+	MustNot []queryClause
+}
+
+// parseQuery parses a query string into field-qualified, phrase and
+// negated clauses.
+//
+// Supported syntax:
+//   - bare terms:        hello world
+//   - field-qualified:   sender:foo tag:bar
+//   - quoted phrases:    "exact phrase" sender:"exact phrase"
+//   - negation:          -term -"exact phrase" -sender:foo
+func parseQuery(query string) *parsedQuery {
+	parsed := &parsedQuery{}
+
+	for _, raw := range splitQueryTokens(query) {
+		clause, ok := parseQueryToken(raw)
+		if !ok {
+			continue
+		}
+		if clause.Negate {
+			parsed.MustNot = append(parsed.MustNot, clause)
+		} else {
+			parsed.Must = append(parsed.Must, clause)
+		}
+	}
+
+	return parsed
+}
+
+// splitQueryTokens splits a query string on whitespace, keeping quoted
+// substrings (e.g. `"exact phrase"`) together as a single token.
+func splitQueryTokens(query string) []string {
+	var tokens []string
+	var b strings.Builder
+	inQuotes := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if b.Len() > 0 {
+				tokens = append(tokens, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		tokens = append(tokens, b.String())
+	}
+
+	return tokens
+}
+
+// summarizeQueryTerms renders a parsed query's clauses as short strings
+// (e.g. "sender:foo", `-"exact phrase"`) suitable for a log field.
+func summarizeQueryTerms(parsed *parsedQuery) []string {
+	terms := make([]string, 0, len(parsed.Must)+len(parsed.MustNot))
+	for _, c := range parsed.Must {
+		terms = append(terms, c.String())
+	}
+	for _, c := range parsed.MustNot {
+		terms = append(terms, "-"+c.String())
+	}
+	return terms
+}
+
+// String renders a clause back to a query-syntax fragment, ignoring Negate
+// (callers that care about negation prefix it themselves).
+func (c queryClause) String() string {
+	term := strings.Join(c.Terms, " ")
+	if len(c.Terms) > 1 {
+		term = `"` + term + `"`
+	}
+	if c.Field != "" {
+		return c.Field + ":" + term
+	}
+	return term
+}
+
+// parseQueryToken parses a single token (as produced by splitQueryTokens)
+// into a queryClause. ok is false if the token carries no searchable terms.
+func parseQueryToken(token string) (queryClause, bool) {
+	var clause queryClause
+
+	if strings.HasPrefix(token, "-") {
+		clause.Negate = true
+		token = token[1:]
+	}
+
+	if colonIdx := strings.Index(token, ":"); colonIdx > 0 && !strings.HasPrefix(token, `"`) {
+		clause.Field = strings.ToLower(token[:colonIdx])
+		token = token[colonIdx+1:]
+	}
+
+	if len(token) >= 2 && strings.HasPrefix(token, `"`) && strings.HasSuffix(token, `"`) {
+		token = token[1 : len(token)-1]
+	}
+
+	clause.Terms = tokenize(token)
+	if len(clause.Terms) == 0 {
+		return clause, false
+	}
+
+	return clause, true
+}
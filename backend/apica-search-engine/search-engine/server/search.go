@@ -1,269 +1,1167 @@
 package server
 
 import (
+	"container/heap"
+	"container/list"
 	"context"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/apache/arrow/go/arrow/memory"
 	"github.com/apache/arrow/go/v13/arrow"
 	"github.com/apache/arrow/go/v13/arrow/array"
-	"github.com/apache/arrow/go/v13/parquet"
+	"github.com/apache/arrow/go/v13/parquet/file"
 	"github.com/apache/arrow/go/v13/parquet/pqarrow"
-	"github.com/sirupsen/logrus"
+)
+
+// DefaultBM25K1 and DefaultBM25B are the standard BM25 tuning defaults.
+const (
+	DefaultBM25K1 = 1.2
+	DefaultBM25B  = 0.75
 )
 
 type Document struct {
-	ID            string
-	Message       string
-	MessageRaw    string
+	ID             string
+	Message        string
+	MessageRaw     string
 	StructuredData string
-	Tag           string
-	Sender        string
-	Groupings     string
-	Event         string
-	EventID       string
-	Timestamp     int64
-	Namespace     string
+	Tag            string
+	Sender         string
+	Groupings      string
+	Event          string
+	EventID        string
+	Timestamp      int64
+	Namespace      string
+	// TermCount is the document's length in indexed tokens, used to
+	// normalize BM25 scores against the average document length.
+	TermCount int
+}
+
+// DefaultShardCount is the number of shards a SearchIndex created with
+// NewSearchIndex partitions its documents and postings across.
+const DefaultShardCount = 16
+
+// shard owns a disjoint slice of the index's documents and postings behind
+// its own mutex, so ingest workers writing to one shard never block a
+// search reading another.
+//
+// When a shard is store-backed and capped (SearchIndex.MaxCachedDocuments >
+// 0), documents is a bounded FIFO cache rather than the full resident set:
+// docOrder/docElems track insertion order so the oldest entry can be
+// evicted, while docTermCounts retains every document's TermCount forever
+// (it's a handful of bytes per document, not the full body) so avgDocLength
+// stays accurate even for evicted documents. An evicted document is
+// re-fetched from the store on its next read (see documentsByID).
+type shard struct {
+	mutex         sync.RWMutex
+	documents     map[string]*Document
+	docOrder      *list.List               // FIFO order of cached document IDs; front = most recently inserted
+	docElems      map[string]*list.Element // docID -> its element in docOrder
+	docTermCounts map[string]int           // docID -> TermCount, retained even once evicted from documents
+	// docTermKeys records, per docID, every inverted-index key (term or
+	// "field:term") its most recent IndexDocument call wrote postings
+	// under - not just the ones currently cached in documents - so a later
+	// re-index of the same ID can tell which keys from the previous
+	// version are no longer present in the new one and clear their stale
+	// postings instead of leaving them to match forever.
+	docTermKeys   map[string][]string
+	invertedIndex map[string]map[string][]int // Term -> docID -> positions
+}
+
+func newShard() *shard {
+	return &shard{
+		documents:     make(map[string]*Document),
+		docOrder:      list.New(),
+		docElems:      make(map[string]*list.Element),
+		docTermCounts: make(map[string]int),
+		docTermKeys:   make(map[string][]string),
+		invertedIndex: make(map[string]map[string][]int),
+	}
 }
 
-// SearchIndex represents our in-memory search engine
+// cacheDocument stores doc in the shard's document cache, evicting the
+// oldest cached document if maxCached is positive and the cache is now
+// over that bound. maxCached <= 0 means unbounded. Callers must hold
+// sh.mutex for writing.
+func (sh *shard) cacheDocument(doc *Document, maxCached int) {
+	if _, exists := sh.docElems[doc.ID]; !exists {
+		sh.docElems[doc.ID] = sh.docOrder.PushFront(doc.ID)
+	}
+	sh.documents[doc.ID] = doc
+
+	if maxCached <= 0 {
+		return
+	}
+	for sh.docOrder.Len() > maxCached {
+		oldest := sh.docOrder.Back()
+		if oldest == nil {
+			break
+		}
+		oldestID := oldest.Value.(string)
+		sh.docOrder.Remove(oldest)
+		delete(sh.docElems, oldestID)
+		delete(sh.documents, oldestID)
+	}
+}
+
+// SearchIndex represents our search engine. Documents and the inverted
+// index are partitioned across a fixed number of shards, each mirrored to
+// a backing IndexStore (when one is configured) so the index survives
+// restarts.
+//
+// A persistent index (NewPersistentSearchIndex) additionally bounds how
+// many document bodies it keeps resident to MaxCachedDocuments: beyond
+// that, a shard's oldest cached document is evicted from memory (its
+// TermCount and postings never are) and lazily re-fetched from the store
+// the next time a search result needs it (documentsByID). That decouples
+// the corpus size a persistent index can serve from how much of it fits in
+// RAM at once - the inverted index and the document cache are still
+// resident, but the full set of document bodies no longer has to be.
+//
+// A document's shard is chosen by hashing its ID; a term's (or a
+// "field:term" qualified key's, see queryClause.indexKey) shard is chosen
+// independently by hashing the term itself, so document writes and
+// postings writes for the same IndexDocument call can land on different
+// shards and proceed without contending on each other.
 type SearchIndex struct {
-	Documents    map[string]*Document         // Document storage
-	InvertedIndex map[string]map[string]bool  // Term -> document IDs mapping
-	mutex        sync.RWMutex                 // For concurrent access
+	shards    []*shard
+	shardMask uint32     // len(shards)-1; shards is always a power of two
+	store     IndexStore // Optional on-disk backing store
+	logger    Logger     // Never nil; defaults to a no-op
+	BM25K1    float64    // BM25 term frequency saturation parameter
+	BM25B     float64    // BM25 document length normalization parameter
+	// MaxCachedDocuments bounds how many documents, per shard, a
+	// store-backed index keeps resident at once. Zero (the default for
+	// NewSearchIndex/NewShardedSearchIndex) means unbounded, since an
+	// index with no store has nowhere to re-fetch an evicted document
+	// from.
+	MaxCachedDocuments int
+
+	// totalDocs and totalTermCount back avgDocLength: maintaining them as
+	// running totals in IndexDocument means avgDocLength doesn't need to
+	// visit every shard's (possibly partially evicted) document map to
+	// recompute them.
+	totalDocs      atomic.Int64
+	totalTermCount atomic.Int64
+
+	// dirtyMu guards dirtyTerms, the set of terms IndexDocument has
+	// touched since the last flush to store. Posting lists are batched
+	// here and written out periodically by flushPostings rather than
+	// rewritten in full on every IndexDocument call, since a common term's
+	// posting list otherwise gets one O(postings size) disk write per
+	// document indexed.
+	dirtyMu    sync.Mutex
+	dirtyTerms map[string]struct{}
+	flushStop  chan struct{}
+	flushDone  chan struct{}
+	closeOnce  sync.Once
 }
 
-// NewSearchIndex creates a new search index
+// NewSearchIndex creates a new, empty in-memory search index with
+// DefaultShardCount shards, no persistence, and the default BM25
+// parameters.
 func NewSearchIndex() *SearchIndex {
+	return NewShardedSearchIndex(DefaultShardCount)
+}
+
+// NewShardedSearchIndex creates a new, empty in-memory search index with no
+// persistence, partitioned across shardCount shards (rounded up to the
+// nearest power of two, minimum 1).
+func NewShardedSearchIndex(shardCount int) *SearchIndex {
+	shardCount = nextPowerOfTwo(shardCount)
+	shards := make([]*shard, shardCount)
+	for i := range shards {
+		shards[i] = newShard()
+	}
 	return &SearchIndex{
-		Documents:    make(map[string]*Document),
-		InvertedIndex: make(map[string]map[string]bool),
+		shards:     shards,
+		shardMask:  uint32(shardCount - 1),
+		logger:     noopLogger{},
+		BM25K1:     DefaultBM25K1,
+		BM25B:      DefaultBM25B,
+		dirtyTerms: make(map[string]struct{}),
 	}
 }
 
-// IndexDocument adds a document to the search index
-func (idx *SearchIndex) IndexDocument(doc *Document) {
-	idx.mutex.Lock()
-	defer idx.mutex.Unlock()
+// nextPowerOfTwo returns the smallest power of two >= n, or 1 if n <= 1.
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// SetLogger points idx's internal error logging (e.g. failed store writes)
+// at logger instead of the default no-op.
+func (idx *SearchIndex) SetLogger(logger Logger) {
+	idx.logger = logger
+}
+
+// DefaultMaxCachedDocuments is the MaxCachedDocuments a persistent
+// SearchIndex is created with.
+const DefaultMaxCachedDocuments = 100_000
+
+// NewPersistentSearchIndex creates a search index backed by store, loading
+// postings and every document's TermCount (needed for avgDocLength) from
+// disk and distributing them across the index's shards, and warming each
+// shard's document cache up to MaxCachedDocuments. This one-time startup
+// pass decodes the whole corpus, but - unlike the document cache itself -
+// does not keep more than MaxCachedDocuments documents per shard resident
+// afterward; the rest are re-fetched from store on demand.
+func NewPersistentSearchIndex(store IndexStore) (*SearchIndex, error) {
+	idx := NewSearchIndex()
+	idx.store = store
+	idx.MaxCachedDocuments = DefaultMaxCachedDocuments
+
+	docs, err := store.LoadDocuments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load documents from store: %w", err)
+	}
+	for docID, doc := range docs {
+		sh := idx.shards[idx.shardIndexForDoc(docID)]
+		sh.mutex.Lock()
+		sh.docTermCounts[docID] = doc.TermCount
+		sh.cacheDocument(doc, idx.MaxCachedDocuments)
+		sh.mutex.Unlock()
+		idx.totalDocs.Add(1)
+		idx.totalTermCount.Add(int64(doc.TermCount))
+	}
 
-	// Store the document
-	idx.Documents[doc.ID] = doc
-	
-	// Index the document terms
-	terms := tokenize(doc.Message + " " + doc.Tag + " " + doc.Sender + " " + doc.Event)
-	for _, term := range terms {
-		if idx.InvertedIndex[term] == nil {
-			idx.InvertedIndex[term] = make(map[string]bool)
+	postings, err := store.LoadPostings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load postings from store: %w", err)
+	}
+	for term, docPositions := range postings {
+		sh := idx.shards[idx.shardIndexForTerm(term)]
+		sh.invertedIndex[term] = docPositions
+
+		// Rebuild each posted-to document's docTermKeys from the postings
+		// themselves, so a re-index after a restart still has an accurate
+		// "previous version's keys" set to diff against (see IndexDocument).
+		for docID := range docPositions {
+			docSh := idx.shards[idx.shardIndexForDoc(docID)]
+			docSh.docTermKeys[docID] = append(docSh.docTermKeys[docID], term)
 		}
-		idx.InvertedIndex[term][doc.ID] = true
 	}
+
+	idx.startPostingFlusher(postingFlushInterval)
+
+	return idx, nil
 }
 
-// Search performs a search on the index
-func (idx *SearchIndex) Search(query string) ([]*Document, time.Duration) {
-	startTime := time.Now()
-	
-	idx.mutex.RLock()
-	defer idx.mutex.RUnlock()
-	
-	queryTerms := tokenize(query)
-	if len(queryTerms) == 0 {
-		return []*Document{}, time.Since(startTime)
-	}
-	
-	// Find documents containing all query terms (AND logic)
-	var matchingIDs map[string]bool
-	
-	// Start with the first term
-	firstTerm := queryTerms[0]
-	if idx.InvertedIndex[firstTerm] != nil {
-		matchingIDs = make(map[string]bool)
-		for id := range idx.InvertedIndex[firstTerm] {
-			matchingIDs[id] = true
+// postingFlushInterval is how often a persistent SearchIndex writes out
+// the posting lists IndexDocument has touched since the last flush.
+const postingFlushInterval = 2 * time.Second
+
+// startPostingFlusher starts the background goroutine that periodically
+// writes dirtyTerms' posting lists to idx.store. Callers must set idx.store
+// before calling this.
+func (idx *SearchIndex) startPostingFlusher(interval time.Duration) {
+	idx.flushStop = make(chan struct{})
+	idx.flushDone = make(chan struct{})
+
+	go func() {
+		defer close(idx.flushDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				idx.flushPostings()
+			case <-idx.flushStop:
+				idx.flushPostings()
+				return
+			}
+		}
+	}()
+}
+
+// markTermsDirty records that terms' posting lists have changed since the
+// last flush to idx.store.
+func (idx *SearchIndex) markTermsDirty(terms map[string]struct{}) {
+	idx.dirtyMu.Lock()
+	defer idx.dirtyMu.Unlock()
+	for term := range terms {
+		idx.dirtyTerms[term] = struct{}{}
+	}
+}
+
+// flushPostings writes every term marked dirty since the last flush to
+// idx.store, each as a single full rewrite of that term's current posting
+// list - batching many IndexDocument calls' worth of changes to a term
+// into one disk write instead of one per document indexed.
+func (idx *SearchIndex) flushPostings() {
+	idx.dirtyMu.Lock()
+	terms := idx.dirtyTerms
+	idx.dirtyTerms = make(map[string]struct{})
+	idx.dirtyMu.Unlock()
+
+	for term := range terms {
+		sh := idx.shards[idx.shardIndexForTerm(term)]
+		sh.mutex.RLock()
+		postings := sh.invertedIndex[term]
+		sh.mutex.RUnlock()
+
+		if err := idx.store.SavePosting(term, postings); err != nil {
+			idx.logger.Errorf("failed to persist posting list for %q: %v", term, err)
+		}
+	}
+}
+
+// Close stops idx's background posting flusher, if NewPersistentSearchIndex
+// started one, performing one final flush first so no pending posting
+// write is lost. It does not close the underlying IndexStore.
+func (idx *SearchIndex) Close() {
+	idx.closeOnce.Do(func() {
+		if idx.flushStop == nil {
+			return
+		}
+		close(idx.flushStop)
+		<-idx.flushDone
+	})
+}
+
+// shardIndexForDoc returns the index into idx.shards that owns document
+// docID.
+func (idx *SearchIndex) shardIndexForDoc(docID string) int {
+	return int(fnv32(docID) & idx.shardMask)
+}
+
+// shardIndexForTerm returns the index into idx.shards that owns term's
+// postings.
+func (idx *SearchIndex) shardIndexForTerm(term string) int {
+	return int(fnv32(term) & idx.shardMask)
+}
+
+func fnv32(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
+
+// IndexDocument adds a document to the search index. Calling it again for
+// an ID that's already indexed replaces that document's postings rather
+// than appending to them, so re-indexing (Application.Reindex, or a
+// retried ingest) is idempotent instead of inflating term frequencies.
+func (idx *SearchIndex) IndexDocument(doc *Document) {
+	// Tokenize every indexed field, tracking the running token position
+	// across fields (with a one-position gap between fields) so that
+	// phrase queries never match across a field boundary, and group the
+	// resulting postings by the shard that owns each term so every shard
+	// is locked just once. This - and setting doc.TermCount - happens
+	// before doc is published into docShard.documents below, so a
+	// concurrent Search can never observe doc with a stale or partially
+	// written TermCount.
+	type occurrence struct {
+		term     string
+		position int
+	}
+	byShard := make(map[int][]occurrence)
+	newKeys := make(map[string]struct{})
+	var newKeyList []string
+	position := 0
+	termCount := 0
+	for _, field := range indexedFields {
+		for _, term := range tokenize(fieldValue(doc, field)) {
+			for _, key := range [2]string{term, field + ":" + term} {
+				s := idx.shardIndexForTerm(key)
+				byShard[s] = append(byShard[s], occurrence{term: key, position: position})
+				if _, exists := newKeys[key]; !exists {
+					newKeys[key] = struct{}{}
+					newKeyList = append(newKeyList, key)
+				}
+			}
+			position++
+			termCount++
 		}
+		position++
+	}
+	doc.TermCount = termCount
+
+	docShard := idx.shards[idx.shardIndexForDoc(doc.ID)]
+	docShard.mutex.Lock()
+	oldTermCount, hadOld := docShard.docTermCounts[doc.ID]
+	oldKeys := docShard.docTermKeys[doc.ID]
+	docShard.docTermCounts[doc.ID] = doc.TermCount
+	docShard.docTermKeys[doc.ID] = newKeyList
+	docShard.cacheDocument(doc, idx.MaxCachedDocuments)
+	docShard.mutex.Unlock()
+
+	if hadOld {
+		idx.totalTermCount.Add(int64(doc.TermCount - oldTermCount))
 	} else {
-		return []*Document{}, time.Since(startTime)
-	}
-	
-	// Intersect with remaining terms
-	for _, term := range queryTerms[1:] {
-		if idx.InvertedIndex[term] == nil {
-			return []*Document{}, time.Since(startTime)
-		}
-		
-		// Perform intersection
-		for id := range matchingIDs {
-			if !idx.InvertedIndex[term][id] {
-				delete(matchingIDs, id)
+		idx.totalDocs.Add(1)
+		idx.totalTermCount.Add(int64(doc.TermCount))
+	}
+
+	// staleByShard collects, per shard, the keys doc.ID was posted under the
+	// last time it was indexed but that no longer appear in this version -
+	// e.g. a term from a Message that has since changed. Left alone these
+	// would match doc.ID forever, so they're deleted outright rather than
+	// reset-and-rewritten like byShard's keys below.
+	staleByShard := make(map[int][]string)
+	for _, key := range oldKeys {
+		if _, stillPresent := newKeys[key]; !stillPresent {
+			s := idx.shardIndexForTerm(key)
+			staleByShard[s] = append(staleByShard[s], key)
+		}
+	}
+
+	// resetTerms tracks, across every shard, which terms have already had
+	// doc.ID's stale position list cleared during this call, so re-indexing
+	// the same document (Application.Reindex, or a retried ingest) replaces
+	// its positions instead of appending to them and inflating tf forever.
+	resetTerms := make(map[string]struct{}, len(byShard))
+	touched := make(map[string]struct{})
+	shardsTouched := make(map[int]struct{}, len(byShard)+len(staleByShard))
+	for s := range byShard {
+		shardsTouched[s] = struct{}{}
+	}
+	for s := range staleByShard {
+		shardsTouched[s] = struct{}{}
+	}
+	for s := range shardsTouched {
+		sh := idx.shards[s]
+		sh.mutex.Lock()
+		for _, occ := range byShard[s] {
+			if sh.invertedIndex[occ.term] == nil {
+				sh.invertedIndex[occ.term] = make(map[string][]int)
+			}
+			if _, alreadyReset := resetTerms[occ.term]; !alreadyReset {
+				sh.invertedIndex[occ.term][doc.ID] = sh.invertedIndex[occ.term][doc.ID][:0]
+				resetTerms[occ.term] = struct{}{}
+			}
+			sh.invertedIndex[occ.term][doc.ID] = append(sh.invertedIndex[occ.term][doc.ID], occ.position)
+			touched[occ.term] = struct{}{}
+		}
+		for _, key := range staleByShard[s] {
+			if postings, ok := sh.invertedIndex[key]; ok {
+				if _, existed := postings[doc.ID]; existed {
+					delete(postings, doc.ID)
+					touched[key] = struct{}{}
+				}
+			}
+		}
+		sh.mutex.Unlock()
+	}
+
+	if idx.store != nil {
+		if err := idx.store.SaveDocument(doc); err != nil {
+			idx.logger.Errorf("failed to persist document %s: %v", doc.ID, err)
+		}
+		idx.markTermsDirty(touched)
+	}
+}
+
+// termPostings returns a copy of every docID->positions entry stored under
+// key, fetched from its owning shard under a brief read lock so the result
+// stays valid after the shard is unlocked.
+func (idx *SearchIndex) termPostings(key string) map[string][]int {
+	sh := idx.shards[idx.shardIndexForTerm(key)]
+	sh.mutex.RLock()
+	defer sh.mutex.RUnlock()
+
+	src := sh.invertedIndex[key]
+	if len(src) == 0 {
+		return nil
+	}
+	out := make(map[string][]int, len(src))
+	for docID, positions := range src {
+		cp := make([]int, len(positions))
+		copy(cp, positions)
+		out[docID] = cp
+	}
+	return out
+}
+
+// matchClause returns the documents the clause matches (mapped to the
+// starting positions of the match - a single term's own positions for a
+// plain clause, or the positions where all of a phrase's terms occur
+// adjacently) and the clause's first term's document frequency, fanning
+// the per-term shard lookups out concurrently when there's more than one.
+func (idx *SearchIndex) matchClause(c queryClause) (hits map[string][]int, df int) {
+	if len(c.Terms) == 0 {
+		return nil, 0
+	}
+
+	if len(c.Terms) == 1 {
+		hits = idx.termPostings(c.indexKey(c.Terms[0]))
+		return hits, len(hits)
+	}
+
+	postings := make([]map[string][]int, len(c.Terms))
+	var wg sync.WaitGroup
+	wg.Add(len(c.Terms))
+	for i, term := range c.Terms {
+		go func(i int, term string) {
+			defer wg.Done()
+			postings[i] = idx.termPostings(c.indexKey(term))
+		}(i, term)
+	}
+	wg.Wait()
+
+	matches := make(map[string][]int)
+	for docID, startPositions := range postings[0] {
+	nextStart:
+		for _, start := range startPositions {
+			for i := 1; i < len(c.Terms); i++ {
+				if !containsInt(postings[i][docID], start+i) {
+					continue nextStart
+				}
+			}
+			matches[docID] = append(matches[docID], start)
+		}
+	}
+	return matches, len(postings[0])
+}
+
+func containsInt(values []int, target int) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// documentsByID fetches every document in ids by its ID, grouping lookups
+// by owning shard so each shard is read-locked at most once. A document
+// evicted from its shard's cache (see shard.cacheDocument) is lazily
+// re-fetched from idx.store and re-cached before being returned.
+func (idx *SearchIndex) documentsByID(ids []string) map[string]*Document {
+	byShard := make(map[int][]string)
+	for _, id := range ids {
+		s := idx.shardIndexForDoc(id)
+		byShard[s] = append(byShard[s], id)
+	}
+
+	type found struct {
+		id  string
+		doc *Document
+	}
+	results := make(chan []found, len(byShard))
+	var wg sync.WaitGroup
+	wg.Add(len(byShard))
+	for s, shardIDs := range byShard {
+		go func(s int, shardIDs []string) {
+			defer wg.Done()
+			sh := idx.shards[s]
+
+			docs := make([]found, 0, len(shardIDs))
+			var misses []string
+			sh.mutex.RLock()
+			for _, id := range shardIDs {
+				if doc := sh.documents[id]; doc != nil {
+					docs = append(docs, found{id: id, doc: doc})
+				} else {
+					misses = append(misses, id)
+				}
+			}
+			sh.mutex.RUnlock()
+
+			for _, id := range misses {
+				if idx.store == nil {
+					continue
+				}
+				doc, ok, err := idx.store.LoadDocument(id)
+				if err != nil {
+					idx.logger.Errorf("failed to load document %s from store: %v", id, err)
+					continue
+				}
+				if !ok {
+					continue
+				}
+				sh.mutex.Lock()
+				sh.cacheDocument(doc, idx.MaxCachedDocuments)
+				sh.mutex.Unlock()
+				docs = append(docs, found{id: id, doc: doc})
+			}
+
+			results <- docs
+		}(s, shardIDs)
+	}
+	wg.Wait()
+	close(results)
+
+	docs := make(map[string]*Document, len(ids))
+	for batch := range results {
+		for _, f := range batch {
+			docs[f.id] = f.doc
+		}
+	}
+	return docs
+}
+
+// avgDocLength returns the mean TermCount and the total document count
+// across the whole index, including documents currently evicted from their
+// shard's cache (see shard.docTermCounts).
+func (idx *SearchIndex) avgDocLength() (avgdl float64, docCount int) {
+	docCount = int(idx.totalDocs.Load())
+	if docCount == 0 {
+		return 0, 0
+	}
+	return float64(idx.totalTermCount.Load()) / float64(docCount), docCount
+}
+
+// idHeapItem is a single list's current head in a k-way sorted merge.
+type idHeapItem struct {
+	value string
+	list  int
+}
+
+// idHeap is a min-heap of idHeapItems ordered by value, used to intersect
+// several sorted document ID lists without building a hash set per list.
+type idHeap []idHeapItem
+
+func (h idHeap) Len() int            { return len(h) }
+func (h idHeap) Less(i, j int) bool  { return h[i].value < h[j].value }
+func (h idHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *idHeap) Push(x interface{}) { *h = append(*h, x.(idHeapItem)) }
+func (h *idHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// intersectSortedIDs returns the IDs common to every one of lists (each of
+// which must already be sorted), merging them with a min-heap the way a
+// multi-way sorted join would rather than building and probing a hash set
+// per list.
+func intersectSortedIDs(lists [][]string) []string {
+	if len(lists) == 0 {
+		return nil
+	}
+	for _, list := range lists {
+		if len(list) == 0 {
+			return nil
+		}
+	}
+
+	ptrs := make([]int, len(lists))
+	h := make(idHeap, 0, len(lists))
+	for i, list := range lists {
+		h = append(h, idHeapItem{value: list[0], list: i})
+	}
+	heap.Init(&h)
+
+	var result []string
+	for h.Len() > 0 {
+		value := h[0].value
+		matched := 0
+		exhausted := false
+		for h.Len() > 0 && h[0].value == value {
+			item := heap.Pop(&h).(idHeapItem)
+			matched++
+			ptrs[item.list]++
+			if ptrs[item.list] < len(lists[item.list]) {
+				heap.Push(&h, idHeapItem{value: lists[item.list][ptrs[item.list]], list: item.list})
+			} else {
+				exhausted = true
 			}
 		}
-		
-		if len(matchingIDs) == 0 {
-			return []*Document{}, time.Since(startTime)
+		if matched == len(lists) {
+			result = append(result, value)
+		}
+		if exhausted {
+			// This list has no more candidates, so no further value can
+			// ever match every list.
+			break
 		}
 	}
-	
-	// Collect matching documents
-	results := make([]*Document, 0, len(matchingIDs))
-	for id := range matchingIDs {
-		results = append(results, idx.Documents[id])
+	return result
+}
+
+// RankedResult is a single search hit, scored and with the offsets of the
+// query terms that matched it so a UI can highlight them.
+type RankedResult struct {
+	Document   *Document   `json:"document"`
+	Score      float64     `json:"score"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Search parses query (supporting field-qualified terms, quoted phrases
+// and -negation), finds the documents matching every positive clause and
+// none of the negative ones, and ranks them by BM25 score.
+func (idx *SearchIndex) Search(query string) ([]*RankedResult, time.Duration) {
+	startTime := time.Now()
+
+	parsed := parseQuery(query)
+	if len(parsed.Must) == 0 {
+		return []*RankedResult{}, time.Since(startTime)
+	}
+
+	type clauseMatch struct {
+		clause queryClause
+		hits   map[string][]int
+		df     float64
+	}
+	clauseMatches := make([]clauseMatch, len(parsed.Must))
+	var wg sync.WaitGroup
+	wg.Add(len(parsed.Must))
+	for i, c := range parsed.Must {
+		go func(i int, c queryClause) {
+			defer wg.Done()
+			hits, df := idx.matchClause(c)
+			clauseMatches[i] = clauseMatch{clause: c, hits: hits, df: float64(df)}
+		}(i, c)
 	}
-	
+	wg.Wait()
+
+	// AND the positive clauses together via a k-way sorted merge of their
+	// matching document IDs, rather than probing a hash set per clause.
+	sortedIDs := make([][]string, len(clauseMatches))
+	for i, cm := range clauseMatches {
+		ids := make([]string, 0, len(cm.hits))
+		for id := range cm.hits {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		sortedIDs[i] = ids
+	}
+	matchingIDs := intersectSortedIDs(sortedIDs)
+	if len(matchingIDs) == 0 {
+		return []*RankedResult{}, time.Since(startTime)
+	}
+
+	// Drop anything matching a negated clause.
+	if len(parsed.MustNot) > 0 {
+		excluded := make(map[string]struct{})
+		var negWG sync.WaitGroup
+		var negMu sync.Mutex
+		negWG.Add(len(parsed.MustNot))
+		for _, c := range parsed.MustNot {
+			go func(c queryClause) {
+				defer negWG.Done()
+				hits, _ := idx.matchClause(c)
+				negMu.Lock()
+				defer negMu.Unlock()
+				for id := range hits {
+					excluded[id] = struct{}{}
+				}
+			}(c)
+		}
+		negWG.Wait()
+
+		filtered := matchingIDs[:0]
+		for _, id := range matchingIDs {
+			if _, ok := excluded[id]; !ok {
+				filtered = append(filtered, id)
+			}
+		}
+		matchingIDs = filtered
+	}
+
+	if len(matchingIDs) == 0 {
+		return []*RankedResult{}, time.Since(startTime)
+	}
+
+	// Gather the matching documents themselves in parallel, one read lock
+	// per owning shard.
+	docs := idx.documentsByID(matchingIDs)
+
+	avgdl, docCount := idx.avgDocLength()
+	n := float64(docCount)
+	if avgdl == 0 {
+		avgdl = 1
+	}
+
+	results := make([]*RankedResult, 0, len(matchingIDs))
+	for _, id := range matchingIDs {
+		doc := docs[id]
+		if doc == nil {
+			continue
+		}
+
+		var score float64
+		for _, cm := range clauseMatches {
+			tf := float64(len(cm.hits[id]))
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log((n-cm.df+0.5)/(cm.df+0.5) + 1)
+			norm := tf + idx.BM25K1*(1-idx.BM25B+idx.BM25B*(float64(doc.TermCount)/avgdl))
+			score += idf * (tf * (idx.BM25K1 + 1)) / norm
+		}
+
+		results = append(results, &RankedResult{
+			Document:   doc,
+			Score:      score,
+			Highlights: highlightsForDoc(doc, parsed.Must),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+
 	return results, time.Since(startTime)
 }
 
+// Indexer is anything that can add a Document to a search index. Ingest
+// code depends on this interface rather than a concrete *SearchIndex so it
+// can be driven from an explicit index argument instead of a package
+// global, which makes background/repeat ingestion and testing safe.
+type Indexer interface {
+	IndexDocument(doc *Document)
+}
 
+// Flusher is implemented by an Indexer whose writes are batched and need an
+// explicit sync point before they can be treated as durable. A SearchIndex's
+// postings are one such case: IndexDocument only marks a term dirty and
+// flushPostings writes it out up to postingFlushInterval later, so ingest
+// code that wants to checkpoint progress past an indexed document must call
+// Flush first (see processRowGroupsConcurrently).
+type Flusher interface {
+	Flush() error
+}
 
-var searchIndex *SearchIndex
+// Flush writes out every posting list touched since the last flush
+// synchronously, instead of waiting for the next postingFlushInterval tick.
+// Like the periodic flush, a failed write is logged rather than returned.
+func (idx *SearchIndex) Flush() error {
+	if idx.store == nil {
+		return nil
+	}
+	idx.flushPostings()
+	return nil
+}
 
 // SearchResult represents the search results returned by the API
 type SearchResult struct {
-	Query         string      `json:"query"`
-	TotalResults  int         `json:"totalResults"`
-	SearchTimeMs  float64     `json:"searchTimeMs"`
-	Results       []*Document `json:"results"`
+	Query        string          `json:"query"`
+	TotalResults int             `json:"totalResults"`
+	SearchTimeMs float64         `json:"searchTimeMs"`
+	Results      []*RankedResult `json:"results"`
 }
 
 const (
-	maxFileWorkers  =  4 // Maximum number of files to process concurrently
-	maxRowsPerBatch = 1000 // Number of rows to process in each batch
+	maxFileWorkers  = 4    // Maximum number of files to process concurrently
+	maxBatchWorkers = 8    // Maximum number of row groups to stream concurrently per file
+	maxRowsPerBatch = 1000 // Arrow record batch size used when iterating row groups
+	indexWorkers    = 4    // Number of goroutines draining docs into the index concurrently
 )
 
-func processParquetFile(filePath string) error {
+// ingestColumns are the parquet columns read while ingesting a file. Only
+// these are decoded off disk (via the column indices processRowGroup passes
+// to GetRecordReader), so a
+// file with many unindexed columns costs no more memory than one with just
+// these.
+var ingestColumns = []string{
+	"Message", "MessageRaw", "StructuredData", "Tag", "Sender",
+	"Groupings", "Event", "EventId", "NanoTimeStamp", "Namespace",
+}
+
+func processParquetFile(filePath string, store IndexStore, indexer Indexer, logger Logger) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// doneRowGroups carries forward whichever row groups a prior,
+	// interrupted run of this same file version already finished, so a
+	// crash partway through resumes after its last completed row group
+	// instead of re-ingesting the whole file from row 0. A checkpoint from
+	// a different ModTime belongs to a since-replaced version of the file
+	// and its progress can't be trusted, so it's discarded.
+	var doneRowGroups map[int]bool
+	if store != nil {
+		if cp, ok, err := store.Checkpoint(filePath); err != nil {
+			return fmt.Errorf("failed to read checkpoint: %w", err)
+		} else if ok && cp.ModTime.Equal(info.ModTime()) {
+			if cp.Done {
+				// Already fully ingested since its last modification; skip it.
+				return nil
+			}
+			doneRowGroups = make(map[int]bool, len(cp.RowGroupsDone))
+			for _, rg := range cp.RowGroupsDone {
+				doneRowGroups[rg] = true
+			}
+		}
+	}
+
 	f, err := os.Open(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer f.Close()
 
-	tbl, err := pqarrow.ReadTable(context.Background(), f, parquet.NewReaderProperties(memory.DefaultAllocator),
-		pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	pf, err := file.NewParquetReader(f)
 	if err != nil {
-		return fmt.Errorf("failed to read table: %w", err)
+		return fmt.Errorf("failed to open parquet reader: %w", err)
 	}
+	defer pf.Close()
 
-	// Process table with concurrent batches
-	return processTableConcurrently(tbl)
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: maxRowsPerBatch}, memory.DefaultAllocator)
+	if err != nil {
+		return fmt.Errorf("failed to create arrow reader: %w", err)
+	}
+
+	saveRowGroupProgress := func(doneSoFar []int) error {
+		if store == nil {
+			return nil
+		}
+		return store.SaveCheckpoint(&Checkpoint{
+			Path:          filePath,
+			ModTime:       info.ModTime(),
+			RowGroupsDone: doneSoFar,
+		})
+	}
+
+	rowsIngested, err := processRowGroupsConcurrently(context.Background(), pf, fr, indexer, filePath, logger, doneRowGroups, saveRowGroupProgress)
+	if err != nil {
+		return err
+	}
+
+	if store != nil {
+		cp := &Checkpoint{
+			Path:     filePath,
+			ModTime:  info.ModTime(),
+			RowCount: rowsIngested,
+			Done:     true,
+		}
+		if err := store.SaveCheckpoint(cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+
+	return nil
 }
 
-func processTableConcurrently(tbl arrow.Table) error {
-	// Get column indices (assuming schema has these fields)
-	colIndices := make(map[string]int)
-	for i := 0; i < int(tbl.NumCols()); i++ {
-		colName := tbl.Column(i).Name()
-		colIndices[colName] = i
+// docEnvelope pairs a Document with the WaitGroup tracking its row group's
+// still-unindexed documents, so an indexWorker can signal completion back to
+// the specific row group that produced it (see processRowGroupsConcurrently).
+type docEnvelope struct {
+	doc        *Document
+	rowGroupWG *sync.WaitGroup
+}
+
+// processRowGroupsConcurrently streams pf's row groups through a bounded
+// worker pool, each worker reading only ingestColumns one Arrow record
+// batch at a time so a large file is never fully materialized in memory.
+// Workers send the Documents they build to a shared channel drained by
+// indexWorkers indexing goroutines, so IndexDocument calls land on the
+// index concurrently; IndexDocument's per-shard locking (server/search.go)
+// makes this safe. Row groups present in doneRowGroups (already ingested
+// by a prior, interrupted run) are skipped; saveProgress is called only
+// once a newly-completed row group's documents have actually been indexed
+// (its docEnvelope WaitGroup reaches zero) and, if the indexer batches
+// writes (Flusher), flushed to the store - not merely handed to the docs
+// channel - with every row group done so far in this file, including ones
+// carried over from doneRowGroups, so a crash can resume past them too
+// without silently dropping whatever hadn't reached the store yet.
+func processRowGroupsConcurrently(ctx context.Context, pf *file.Reader, fr *pqarrow.FileReader, indexer Indexer, filePath string, logger Logger, doneRowGroups map[int]bool, saveProgress func([]int) error) (int64, error) {
+	colIndices := resolveColumnIndices(pf, ingestColumns)
+
+	numRowGroups := pf.NumRowGroups()
+	rowGroupOffsets := make([]int64, numRowGroups)
+	var totalRows int64
+	for i := 0; i < numRowGroups; i++ {
+		rowGroupOffsets[i] = totalRows
+		totalRows += pf.MetaData().RowGroup(i).NumRows()
 	}
 
-	// Calculate the number of batches
-	totalRows := tbl.NumRows()
-	numBatches := (totalRows + int64(maxRowsPerBatch) - 1) / int64(maxRowsPerBatch)
+	flusher, _ := indexer.(Flusher)
 
-	// Use a WaitGroup to wait for all batches to complete
-	var wg sync.WaitGroup
-	wg.Add(int(numBatches))
+	docs := make(chan docEnvelope, maxRowsPerBatch)
+	var indexWG sync.WaitGroup
+	for i := 0; i < indexWorkers; i++ {
+		indexWG.Add(1)
+		go func() {
+			defer indexWG.Done()
+			for env := range docs {
+				indexer.IndexDocument(env.doc)
+				env.rowGroupWG.Done()
+			}
+		}()
+	}
 
-	// Create a channel to control concurrency
-	// This limits the number of goroutines running simultaneously
-	semaphore := make(chan struct{}, 8) // Allow 8 concurrent batch processors
+	semaphore := make(chan struct{}, maxBatchWorkers)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
 
-	// Use mutex to synchronize index access
-	var indexMutex sync.Mutex
+	// progressMu guards doneSoFar, the row groups completed in this run
+	// plus whichever ones doneRowGroups already carried over from a prior
+	// run, so concurrent row groups finishing at the same time don't race
+	// appending to it or checkpointing an incomplete view of it.
+	var progressMu sync.Mutex
+	doneSoFar := make([]int, 0, numRowGroups)
+	for rg := range doneRowGroups {
+		doneSoFar = append(doneSoFar, rg)
+	}
 
-	// Process each batch in a separate goroutine
-	for batchIdx := int64(0); batchIdx < numBatches; batchIdx++ {
-		startRow := batchIdx * int64(maxRowsPerBatch)
-		endRow := startRow + int64(maxRowsPerBatch)
-		if endRow > totalRows {
-			endRow = totalRows
+	for rg := 0; rg < numRowGroups; rg++ {
+		if doneRowGroups[rg] {
+			continue
 		}
-
-		// Acquire semaphore slot
 		semaphore <- struct{}{}
+		wg.Add(1)
 
-		go func(start, end int64) {
+		go func(rowGroup int) {
 			defer wg.Done()
-			defer func() { <-semaphore }() // Release semaphore slot when done
+			defer func() { <-semaphore }()
 
-			// Process rows in this batch
-			processBatch(tbl, colIndices, start, end, &indexMutex)
-		}(startRow, endRow)
+			start := time.Now()
+			var rowGroupWG sync.WaitGroup
+			rows, err := processRowGroup(ctx, fr, colIndices, rowGroup, rowGroupOffsets[rowGroup], filePath, docs, &rowGroupWG)
+			if err != nil {
+				errOnce.Do(func() { firstErr = fmt.Errorf("row group %d: %w", rowGroup, err) })
+				return
+			}
+
+			// Every Document this row group produced has been handed to the
+			// docs channel by now, but not necessarily indexed yet - wait
+			// for the indexWorkers that drained them to finish before this
+			// row group can be checkpointed as done.
+			rowGroupWG.Wait()
+			if flusher != nil {
+				if err := flusher.Flush(); err != nil {
+					logger.Errorf("failed to flush postings for %s: %v", filePath, err)
+				}
+			}
+
+			logger.Infow("row group ingested",
+				"file", filePath,
+				"row_group", rowGroup,
+				"rows", rows,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+
+			if saveProgress == nil {
+				return
+			}
+			progressMu.Lock()
+			doneSoFar = append(doneSoFar, rowGroup)
+			snapshot := append([]int(nil), doneSoFar...)
+			progressMu.Unlock()
+			if err := saveProgress(snapshot); err != nil {
+				logger.Errorf("failed to save row group checkpoint for %s: %v", filePath, err)
+			}
+		}(rg)
 	}
 
-	// Wait for all batches to complete
 	wg.Wait()
-	return nil
+	close(docs)
+	indexWG.Wait()
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return totalRows, nil
 }
 
-func processBatch(tbl arrow.Table, colIndices map[string]int, startRow, endRow int64, indexMutex *sync.Mutex) {
-	// Create functions to extract values safely
-	getString := func(rowIdx int64, colName string) string {
-		if colIdx, exists := colIndices[colName]; exists {
-			col := tbl.Column(colIdx)
-			if rowIdx < int64(col.Len()) {
-				chunk := col.Data().Chunk(0)
-				if chunk != nil {
-					if strArr, ok := chunk.(*array.String); ok {
-						return strArr.Value(int(rowIdx))
-					}
-				}
-			}
+// resolveColumnIndices maps names to their parquet column indices, silently
+// dropping any name absent from pf's schema (an older file may lack a
+// column a newer ingest needs).
+func resolveColumnIndices(pf *file.Reader, names []string) []int {
+	sch := pf.MetaData().Schema
+	indices := make([]int, 0, len(names))
+	for _, name := range names {
+		if idx := sch.ColumnIndexByName(name); idx >= 0 {
+			indices = append(indices, idx)
 		}
-		return ""
 	}
+	return indices
+}
 
-	getInt64 := func(rowIdx int64, colName string) int64 {
-		if colIdx, exists := colIndices[colName]; exists {
-			col := tbl.Column(colIdx)
-			if rowIdx < int64(col.Len()) {
-				chunk := col.Data().Chunk(0)
-				if chunk != nil {
-					if intArr, ok := chunk.(*array.Int64); ok && !intArr.IsNull(int(rowIdx)) {
-						return intArr.Value(int(rowIdx))
-					}
-				}
-			}
-		}
-		return 0
+// processRowGroup reads rowGroup one Arrow record batch at a time, builds a
+// Document per row and sends it to docs, releasing each record as soon as
+// it's consumed so only one batch's worth of rows is ever resident here.
+// Document IDs are scoped to filePath as well as row offset, since two
+// files in the same ParquetPath directory both number their rows from 0
+// and would otherwise collide on the same ID in the index and IndexStore.
+// Every Document is sent with rowGroupWG already incremented for it, so the
+// caller can wait on rowGroupWG to learn when this row group's documents
+// have actually been indexed, not merely handed to docs.
+func processRowGroup(ctx context.Context, fr *pqarrow.FileReader, colIndices []int, rowGroup int, rowOffset int64, filePath string, docs chan<- docEnvelope, rowGroupWG *sync.WaitGroup) (int64, error) {
+	rr, err := fr.GetRecordReader(ctx, colIndices, []int{rowGroup})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create record reader: %w", err)
+	}
+	defer rr.Release()
+
+	colsByName := make(map[string]int, len(rr.Schema().Fields()))
+	for i, field := range rr.Schema().Fields() {
+		colsByName[field.Name] = i
 	}
 
-	// Batch documents to reduce mutex contention
-	batchDocs := make([]*Document, 0, endRow-startRow)
+	var rows int64
+	for {
+		rec, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("failed to read record batch: %w", err)
+		}
 
-	// Process each row in this batch
-	for rowIdx := startRow; rowIdx < endRow; rowIdx++ {
-		doc := &Document{
-			ID:             fmt.Sprintf("%d", rowIdx),
-			Message:        getString(rowIdx, "Message"),
-			MessageRaw:     getString(rowIdx, "MessageRaw"),
-			StructuredData: getString(rowIdx, "StructuredData"),
-			Tag:            getString(rowIdx, "Tag"),
-			Sender:         getString(rowIdx, "Sender"),
-			Groupings:      getString(rowIdx, "Groupings"),
-			Event:          getString(rowIdx, "Event"),
-			EventID:        getString(rowIdx, "EventId"),
-			Timestamp:      getInt64(rowIdx, "NanoTimeStamp"),
-			Namespace:      getString(rowIdx, "Namespace"),
+		for row := 0; row < int(rec.NumRows()); row++ {
+			rowGroupWG.Add(1)
+			docs <- docEnvelope{
+				doc: &Document{
+					ID:             fmt.Sprintf("%s:%d", filePath, rowOffset+rows),
+					Message:        recordString(rec, colsByName, "Message", row),
+					MessageRaw:     recordString(rec, colsByName, "MessageRaw", row),
+					StructuredData: recordString(rec, colsByName, "StructuredData", row),
+					Tag:            recordString(rec, colsByName, "Tag", row),
+					Sender:         recordString(rec, colsByName, "Sender", row),
+					Groupings:      recordString(rec, colsByName, "Groupings", row),
+					Event:          recordString(rec, colsByName, "Event", row),
+					EventID:        recordString(rec, colsByName, "EventId", row),
+					Timestamp:      recordInt64(rec, colsByName, "NanoTimeStamp", row),
+					Namespace:      recordString(rec, colsByName, "Namespace", row),
+				},
+				rowGroupWG: rowGroupWG,
+			}
+			rows++
 		}
+		rec.Release()
+	}
+	return rows, nil
+}
 
-		batchDocs = append(batchDocs, doc)
+// recordString returns the value of the named column at row in rec, or ""
+// if the column wasn't requested or isn't a string column.
+func recordString(rec arrow.Record, colsByName map[string]int, name string, row int) string {
+	idx, ok := colsByName[name]
+	if !ok {
+		return ""
+	}
+	if col, ok := rec.Column(idx).(*array.String); ok && !col.IsNull(row) {
+		return col.Value(row)
 	}
+	return ""
+}
 
-	// Lock once to add all documents in the batch
-	indexMutex.Lock()
-	for _, doc := range batchDocs {
-		searchIndex.IndexDocument(doc)
+// recordInt64 returns the value of the named column at row in rec, or 0 if
+// the column wasn't requested or isn't an int64 column.
+func recordInt64(rec arrow.Record, colsByName map[string]int, name string, row int) int64 {
+	idx, ok := colsByName[name]
+	if !ok {
+		return 0
 	}
-	indexMutex.Unlock()
+	if col, ok := rec.Column(idx).(*array.Int64); ok && !col.IsNull(row) {
+		return col.Value(row)
+	}
+	return 0
 }
 
-func processParquetFiles(folderPath string,logger *logrus.Logger) error {
+func processParquetFiles(folderPath string, logger Logger, store IndexStore, indexer Indexer) error {
 	// Check if the folder exists
-	logger.Infof("Checking folder: %s\n", folderPath)
+	logger.Infof("Checking folder: %s", folderPath)
 	info, err := os.Stat(folderPath)
 	if err != nil {
 		return fmt.Errorf("folder access error: %w", err)
@@ -299,9 +1197,9 @@ func processParquetFiles(folderPath string,logger *logrus.Logger) error {
 			defer func() { <-semaphore }() // Release when done
 
 			filePath := filepath.Join(folderPath, fileName)
-			logger.Infof("Processing file: %s\n", filePath)
+			logger.Infof("Processing file: %s", filePath)
 
-			if err := processParquetFile(filePath); err != nil {
+			if err := processParquetFile(filePath, store, indexer, logger); err != nil {
 				errChan <- fmt.Errorf("error processing %s: %w", filePath, err)
 			}
 		}(file.Name())
@@ -315,7 +1213,7 @@ func processParquetFiles(folderPath string,logger *logrus.Logger) error {
 	var errors []error
 	for err := range errChan {
 		errors = append(errors, err)
-		fmt.Println(err)
+		logger.Errorf("%v", err)
 	}
 
 	if len(errors) > 0 {
@@ -331,4 +1229,4 @@ func tokenize(text string) []string {
 	// Replace non-alphanumeric with spaces
 	// Split by whitespace
 	return strings.Fields(text)
-}
\ No newline at end of file
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// BenchmarkSearchIndexMixedLoad drives concurrent IndexDocument and Search
+// calls against a single-shard index (equivalent to the old single-mutex
+// design) and a multi-shard one, to compare mixed read/write throughput.
+func BenchmarkSearchIndexMixedLoad(b *testing.B) {
+	for _, shardCount := range []int{1, DefaultShardCount} {
+		b.Run(fmt.Sprintf("shards=%d", shardCount), func(b *testing.B) {
+			idx := NewShardedSearchIndex(shardCount)
+			seedBenchDocs(idx, 1000)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var n int
+				for pb.Next() {
+					if n%10 == 0 {
+						idx.IndexDocument(&Document{
+							ID:      fmt.Sprintf("new-%d-%d", shardCount, n),
+							Message: "newly ingested event message",
+							Tag:     "ingest",
+							Sender:  "bench",
+						})
+					} else {
+						idx.Search("message sender:bench")
+					}
+					n++
+				}
+			})
+		})
+	}
+}
+
+func seedBenchDocs(idx *SearchIndex, n int) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			idx.IndexDocument(&Document{
+				ID:      fmt.Sprintf("seed-%d", i),
+				Message: "sample search message content",
+				Tag:     "seed",
+				Sender:  "bench",
+			})
+		}(i)
+	}
+	wg.Wait()
+}
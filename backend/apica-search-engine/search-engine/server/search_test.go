@@ -0,0 +1,168 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestSearchBM25RanksMoreFrequentTermHigher(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.IndexDocument(&Document{ID: "low", Message: "error seen once today"})
+	idx.IndexDocument(&Document{ID: "high", Message: "error error error reported today"})
+
+	results, _ := idx.Search("error")
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Document.ID != "high" {
+		t.Fatalf("expected doc with higher term frequency ranked first, got %q", results[0].Document.ID)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Fatalf("expected high score %v > low score %v", results[0].Score, results[1].Score)
+	}
+}
+
+func TestSearchBM25ScoreMatchesFormula(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.IndexDocument(&Document{ID: "a", Message: "apple banana"})
+	idx.IndexDocument(&Document{ID: "b", Message: "banana cherry"})
+
+	results, _ := idx.Search("apple")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+
+	doc := results[0].Document
+	avgdl, docCount := idx.avgDocLength()
+	n := float64(docCount)
+	df := 1.0
+	tf := 1.0
+	idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+	norm := tf + idx.BM25K1*(1-idx.BM25B+idx.BM25B*(float64(doc.TermCount)/avgdl))
+	want := idf * (tf * (idx.BM25K1 + 1)) / norm
+
+	if math.Abs(results[0].Score-want) > 1e-9 {
+		t.Fatalf("score = %v, want %v", results[0].Score, want)
+	}
+}
+
+func TestSearchPhraseDoesNotMatchAcrossFieldBoundary(t *testing.T) {
+	idx := NewSearchIndex()
+	// "alpha" ends the Message field and "beta" begins the Tag field, so
+	// they're adjacent token positions overall but must not satisfy a
+	// phrase query - IndexDocument leaves a position gap between fields
+	// specifically to prevent this.
+	idx.IndexDocument(&Document{ID: "split", Message: "alpha", Tag: "beta"})
+	idx.IndexDocument(&Document{ID: "together", Message: "alpha beta"})
+
+	results, _ := idx.Search(`"alpha beta"`)
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if results[0].Document.ID != "together" {
+		t.Fatalf("expected only %q to match phrase, got %q", "together", results[0].Document.ID)
+	}
+}
+
+func TestSearchPhraseMatchesAdjacentTermsWithinField(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.IndexDocument(&Document{ID: "match", Message: "the quick brown fox"})
+	idx.IndexDocument(&Document{ID: "nomatch", Message: "quick the brown fox"})
+
+	results, _ := idx.Search(`"quick brown"`)
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if results[0].Document.ID != "match" {
+		t.Fatalf("expected %q to match phrase, got %q", "match", results[0].Document.ID)
+	}
+}
+
+func TestSearchNegationExcludesFromAndMatch(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.IndexDocument(&Document{ID: "keep", Message: "status ok healthy"})
+	idx.IndexDocument(&Document{ID: "drop", Message: "status ok error"})
+
+	results, _ := idx.Search("status ok -error")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if results[0].Document.ID != "keep" {
+		t.Fatalf("expected %q to survive negation, got %q", "keep", results[0].Document.ID)
+	}
+}
+
+func TestSearchNegationOfFieldQualifiedClause(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.IndexDocument(&Document{ID: "good", Message: "event occurred", Sender: "alice"})
+	idx.IndexDocument(&Document{ID: "bad", Message: "event occurred", Sender: "bob"})
+
+	results, _ := idx.Search("event -sender:bob")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if results[0].Document.ID != "good" {
+		t.Fatalf("expected %q to survive negation, got %q", "good", results[0].Document.ID)
+	}
+}
+
+// TestIndexDocumentConcurrentWithSearchIsRaceFree drives IndexDocument and
+// Search from separate goroutines against the same document ID, the way a
+// live ingest and a concurrently served HTTP search both touch the index.
+// It must pass under -race: doc.TermCount is read by Search's scoring loop
+// (via documentsByID), so IndexDocument must finish
+// computing it before publishing the document into the shard map.
+func TestIndexDocumentConcurrentWithSearchIsRaceFree(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.IndexDocument(&Document{ID: "doc1", Message: "initial content"})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			idx.IndexDocument(&Document{ID: "doc1", Message: fmt.Sprintf("updated content %d", i)})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			idx.Search("content")
+		}
+	}()
+	wg.Wait()
+}
+
+func TestIndexDocumentReindexReplacesPositions(t *testing.T) {
+	idx := NewSearchIndex()
+	doc := &Document{ID: "doc1", Message: "repeat repeat repeat"}
+	idx.IndexDocument(doc)
+	idx.IndexDocument(doc)
+	idx.IndexDocument(doc)
+
+	postings := idx.termPostings("repeat")
+	if got := len(postings["doc1"]); got != 3 {
+		t.Fatalf("positions for term %q = %d after 3 re-indexes of the same content, want 3 (not 9)", "repeat", got)
+	}
+}
+
+func TestIndexDocumentReindexDropsTermsRemovedFromNewVersion(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.IndexDocument(&Document{ID: "doc1", Message: "foo bar"})
+	idx.IndexDocument(&Document{ID: "doc1", Message: "bar baz"})
+
+	if postings := idx.termPostings("foo"); len(postings["doc1"]) != 0 {
+		t.Fatalf("doc1 still posted under %q after re-indexing without it: %v", "foo", postings["doc1"])
+	}
+	results, _ := idx.Search("foo")
+	if len(results) != 0 {
+		t.Fatalf("got %d results for dropped term %q, want 0", len(results), "foo")
+	}
+
+	results, _ = idx.Search("baz")
+	if len(results) != 1 || results[0].Document.ID != "doc1" {
+		t.Fatalf("expected doc1 to match new term %q, got %+v", "baz", results)
+	}
+}
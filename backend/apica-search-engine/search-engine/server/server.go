@@ -1,19 +1,26 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/google/uuid"
 )
 
 func DefaultConfig() *Config {
 	return &Config{
 		ListenAddress: ":8080",
-		ParquetPath:  "C:\\Projects\\the-mail\\apica-search-engine\\docs",
+		ParquetPath:   "C:\\Projects\\the-mail\\apica-search-engine\\docs",
+		IndexDir:      ".search-engine-index",
+		IndexBackend:  string(BackendBolt),
+		BM25K1:        DefaultBM25K1,
+		BM25B:         DefaultBM25B,
+		LogFormat:     "text",
+		LogLevel:      "info",
 	}
 }
 
@@ -21,7 +28,19 @@ func DefaultConfig() *Config {
 type Config struct {
 	// ListenAddress is an address the oracle HTTP listens on.
 	ListenAddress string `yaml:"listen-address"`
-	ParquetPath string `yaml:"parquet-path"`
+	ParquetPath   string `yaml:"parquet-path"`
+	// IndexDir is the directory the on-disk IndexStore is rooted at.
+	IndexDir string `yaml:"index-dir"`
+	// IndexBackend selects the IndexStore implementation (e.g. "bolt").
+	IndexBackend string `yaml:"index-backend"`
+	// BM25K1 is the BM25 term frequency saturation parameter.
+	BM25K1 float64 `yaml:"bm25-k1"`
+	// BM25B is the BM25 document length normalization parameter.
+	BM25B float64 `yaml:"bm25-b"`
+	// LogFormat selects the log encoding: "json" or "text".
+	LogFormat string `yaml:"log-format"`
+	// LogLevel is the minimum level logged (debug, info, warn, error).
+	LogLevel string `yaml:"log-level"`
 }
 
 // Valid validates an oracle configuration.
@@ -35,35 +54,54 @@ func (c *Config) Valid() error {
 	if c.ParquetPath == "" {
 		return fmt.Errorf("missing parquet path")
 	}
+	if c.IndexDir == "" {
+		return fmt.Errorf("missing index dir")
+	}
 	return nil
 }
 
 // Application represents the API application
 type Application struct {
-	config *Config
-	logger *logrus.Logger
+	config      *Config
+	logger      Logger
 	searchIndex *SearchIndex
+	indexStore  IndexStore
 }
 
 // New creates a new Application instance
 func New(config *Config) (*Application, error) {
-	logger := logrus.New()
-	
-	// Configure the logger
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
+	logger, err := NewLogger(config.LogFormat, config.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	store, err := NewIndexStore(Backend(config.IndexBackend), config.IndexDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index store: %w", err)
+	}
+
+	idx, err := NewPersistentSearchIndex(store)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to load search index: %w", err)
+	}
+	idx.SetLogger(logger)
+	if config.BM25K1 > 0 {
+		idx.BM25K1 = config.BM25K1
+	}
+	if config.BM25B > 0 {
+		idx.BM25B = config.BM25B
+	}
 
-	searchIndex = NewSearchIndex()
-	
 	app := &Application{
-		config: config,
-		logger: logger,
-		searchIndex: searchIndex,
+		config:      config,
+		logger:      logger,
+		searchIndex: idx,
+		indexStore:  store,
 	}
-	
+
 	// Initialize database connection
-	
+
 	return app, nil
 }
 
@@ -71,48 +109,60 @@ func New(config *Config) (*Application, error) {
 
 // Close cleans up resources
 func (app *Application) Close() {
+	if app.searchIndex != nil {
+		app.searchIndex.Close()
+	}
+	if app.indexStore != nil {
+		if err := app.indexStore.Close(); err != nil {
+			app.logger.Errorf("failed to close index store: %v", err)
+		}
+	}
 	// Close database connections and other resources
-	app.logger.Info("Shutting down application")
+	app.logger.Infof("Shutting down application")
+}
+
+// Reindex ingests app.config.ParquetPath into app.searchIndex. It is safe
+// to call repeatedly, including while the server is already handling
+// search requests, since SearchIndex guards concurrent reads and writes
+// with a mutex per shard.
+func (app *Application) Reindex() error {
+	return processParquetFiles(app.config.ParquetPath, app.logger, app.indexStore, app.searchIndex)
 }
 
 // setupRouter configures the HTTP router with API endpoints
 func (app *Application) setupRouter() http.Handler {
 	// Create a new HTTP router
 	mux := http.NewServeMux()
-	
+
 	// Register API endpoints
-	mux.HandleFunc("/api/search", app.handleSearch)	
-	mux.HandleFunc("/api/health",app.healthCheck)
+	mux.HandleFunc("/api/search", app.handleSearch)
+	mux.HandleFunc("/api/health", app.healthCheck)
 	// You can add middleware here if needed
 	var handler http.Handler = mux
-	
+
 	// Add request logging middleware
 	handler = app.loggingMiddleware(handler)
-	
+
 	return handler
 }
 
-
-
 // Run starts the API server
 func Run(config *Config) error {
 	// ctx := context.Background()
-	
+
 	// Create and initialize the application
 	app, err := New(config)
 	if err != nil {
 		return err
 	}
 	defer app.Close()
-	
+
 	// Log startup information
-	app.logger.WithFields(logrus.Fields{
-		"listen_address": config.ListenAddress,
-	}).Info("Starting API server")
-	
+	app.logger.Infow("starting API server", "listen_address", config.ListenAddress)
+
 	// Configure and set up the HTTP router
 	handler := app.setupRouter()
-	
+
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         config.ListenAddress,
@@ -122,13 +172,15 @@ func Run(config *Config) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	
-	// Process Parquet file
-	err = processParquetFiles(app.config.ParquetPath,app.logger)
-	if err != nil {
-		app.logger.Fatalf("Error processing Parquet file: %v", err)
-	}
-	
+	// Ingest Parquet files in the background so the server can start
+	// answering (partial) search results immediately instead of waiting
+	// for the whole corpus to load.
+	go func() {
+		if err := app.Reindex(); err != nil {
+			app.logger.Errorf("Error processing Parquet files: %v", err)
+		}
+	}()
+
 	// Start the server
 	app.logger.Infof("Server listening on %s", config.ListenAddress)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -145,97 +197,146 @@ func (app *Application) healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"UP"}`))
 }
 
+// requestIDKey is the context key loggingMiddleware stores the
+// request-scoped request ID under.
+type requestIDKey struct{}
+
+// requestLogger returns a Logger scoped to the request ID stashed in ctx by
+// loggingMiddleware, so every log line for a request carries it.
+func (app *Application) requestLogger(ctx context.Context) Logger {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return app.logger.With("request_id", id)
+}
+
 // itemsHandler handles item-related API requests
 func (app *Application) handleSearch(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	
+
 	// Handle preflight requests
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	
+
 	// Only allow GET requests
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	
+
 	// Get query parameter
 	query := r.URL.Query().Get("q")
 	if query == "" {
 		http.Error(w, "Missing query parameter 'q'", http.StatusBadRequest)
 		return
 	}
-	
+
 	// Get pagination parameters
 	pageStr := r.URL.Query().Get("page")
 	pageSizeStr := r.URL.Query().Get("pageSize")
-	
+
 	page := 1
 	pageSize := 10
-	
+
 	if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
 		page = p
 	}
-	
+
 	if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
 		pageSize = ps
 	}
-	
+
 	// Perform search
-	results, duration := searchIndex.Search(query)
-	
+	results, duration := app.searchIndex.Search(query)
+	parsedTerms := summarizeQueryTerms(parseQuery(query))
+
 	// Paginate results
 	startIdx := (page - 1) * pageSize
 	endIdx := startIdx + pageSize
-	
+
 	if startIdx >= len(results) {
 		startIdx = 0
 		endIdx = 0
 	}
-	
+
 	if endIdx > len(results) {
 		endIdx = len(results)
 	}
-	
+
 	paginatedResults := results
 	if startIdx < endIdx {
 		paginatedResults = results[startIdx:endIdx]
 	} else {
-		paginatedResults = []*Document{}
+		paginatedResults = []*RankedResult{}
 	}
-	
+
+	app.requestLogger(r.Context()).Infow("search executed",
+		"query", query,
+		"terms", parsedTerms,
+		"result_count", len(results),
+		"bm25_duration_ms", float64(duration.Microseconds())/1000.0,
+		"page", page,
+		"page_size", pageSize,
+		"start_idx", startIdx,
+		"end_idx", endIdx,
+	)
+
 	// Prepare response
 	response := SearchResult{
-		Query:         query,
-		TotalResults:  len(results),
-		SearchTimeMs:  float64(duration.Microseconds()) / 1000.0,
-		Results:       paginatedResults,
+		Query:        query,
+		TotalResults: len(results),
+		SearchTimeMs: float64(duration.Microseconds()) / 1000.0,
+		Results:      paginatedResults,
 	}
-	
+
 	// Send JSON response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// loggingMiddleware logs HTTP requests
+// responseRecorder wraps http.ResponseWriter to capture the status code
+// and byte count written, for access logging.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// loggingMiddleware generates a request ID, attaches it to the request
+// context so handlers can log with it, and emits a single structured
+// event per request once it completes.
 func (app *Application) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		
-		// Call the next handler
-		next.ServeHTTP(w, r)
-		
-		// Log the request
-		app.logger.WithFields(logrus.Fields{
-			"method":   r.Method,
-			"path":     r.URL.Path,
-			"duration": time.Since(start),
-			"remote":   r.RemoteAddr,
-		}).Info("HTTP request")
+
+		requestID := uuid.NewString()
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		r = r.WithContext(ctx)
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		app.logger.Infow("http request",
+			"request_id", requestID,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Backend identifies an on-disk IndexStore implementation.
+type Backend string
+
+const (
+	// BackendBolt stores the index in a single bbolt file.
+	BackendBolt Backend = "bolt"
+)
+
+var (
+	bucketDocuments   = []byte("documents")
+	bucketPostings    = []byte("postings")
+	bucketCheckpoints = []byte("checkpoints")
+)
+
+// Checkpoint records ingestion progress for a single Parquet file, so a
+// restart can skip a file already marked Done, or resume one that wasn't by
+// skipping the row groups listed in RowGroupsDone. A checkpoint only applies
+// to the file version it was recorded against (ModTime); if the file has
+// since been modified, its old progress is discarded and it's re-ingested
+// from scratch. RowCount is recorded for observability only on a completed
+// file; it is never read back to resume a partial ingest.
+type Checkpoint struct {
+	Path          string    `json:"path"`
+	ModTime       time.Time `json:"modTime"`
+	RowCount      int64     `json:"rowCount"`
+	RowGroupsDone []int     `json:"rowGroupsDone,omitempty"`
+	Done          bool      `json:"done"`
+}
+
+// IndexStore persists the inverted index and document store to disk so a
+// SearchIndex can survive restarts without re-ingesting its Parquet
+// corpus, and so it can grow beyond what fits in RAM: SearchIndex keeps
+// only a bounded per-shard cache of document bodies (see
+// shard.cacheDocument) and falls back to LoadDocument to rehydrate one
+// evicted from the cache at query time.
+type IndexStore interface {
+	// SaveDocument writes or overwrites a document keyed by its ID.
+	SaveDocument(doc *Document) error
+	// LoadDocuments returns every document currently on disk.
+	LoadDocuments() (map[string]*Document, error)
+	// LoadDocument returns a single document by ID, or ok=false if no
+	// document with that ID has been saved.
+	LoadDocument(id string) (doc *Document, ok bool, err error)
+	// SavePosting writes or overwrites the posting list for a term, mapping
+	// each document ID to the positions the term occurs at in that document.
+	SavePosting(term string, postings map[string][]int) error
+	// LoadPostings returns the full inverted index currently on disk.
+	LoadPostings() (map[string]map[string][]int, error)
+	// Checkpoint returns the recorded checkpoint for a file path, if any.
+	Checkpoint(path string) (*Checkpoint, bool, error)
+	// SaveCheckpoint records ingestion progress for a file path.
+	SaveCheckpoint(cp *Checkpoint) error
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// NewIndexStore opens (creating if necessary) an on-disk IndexStore of the
+// given backend, rooted at dir.
+func NewIndexStore(backend Backend, dir string) (IndexStore, error) {
+	switch backend {
+	case BackendBolt, "":
+		return newBoltIndexStore(dir)
+	default:
+		return nil, fmt.Errorf("unsupported index backend %q", backend)
+	}
+}
+
+// boltIndexStore is an IndexStore backed by a single bbolt database file.
+type boltIndexStore struct {
+	db *bolt.DB
+}
+
+func newBoltIndexStore(dir string) (*boltIndexStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create index dir: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, "index.db"), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{bucketDocuments, bucketPostings, bucketCheckpoints} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize buckets: %w", err)
+	}
+
+	return &boltIndexStore{db: db}, nil
+}
+
+func (s *boltIndexStore) SaveDocument(doc *Document) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDocuments).Put([]byte(doc.ID), buf.Bytes())
+	})
+}
+
+func (s *boltIndexStore) LoadDocuments() (map[string]*Document, error) {
+	docs := make(map[string]*Document)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDocuments).ForEach(func(k, v []byte) error {
+			var doc Document
+			if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&doc); err != nil {
+				return fmt.Errorf("failed to decode document %q: %w", k, err)
+			}
+			docs[doc.ID] = &doc
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+func (s *boltIndexStore) LoadDocument(id string) (*Document, bool, error) {
+	var doc *Document
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketDocuments).Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+		var decoded Document
+		if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&decoded); err != nil {
+			return fmt.Errorf("failed to decode document %q: %w", id, err)
+		}
+		doc = &decoded
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return doc, doc != nil, nil
+}
+
+func (s *boltIndexStore) SavePosting(term string, postings map[string][]int) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(postings); err != nil {
+		return fmt.Errorf("failed to encode posting list: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPostings).Put([]byte(term), buf.Bytes())
+	})
+}
+
+func (s *boltIndexStore) LoadPostings() (map[string]map[string][]int, error) {
+	postings := make(map[string]map[string][]int)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPostings).ForEach(func(k, v []byte) error {
+			var docPositions map[string][]int
+			if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&docPositions); err != nil {
+				return fmt.Errorf("failed to decode posting list %q: %w", k, err)
+			}
+			postings[string(k)] = docPositions
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return postings, nil
+}
+
+func (s *boltIndexStore) Checkpoint(path string) (*Checkpoint, bool, error) {
+	var cp *Checkpoint
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketCheckpoints).Get([]byte(path))
+		if v == nil {
+			return nil
+		}
+		var decoded Checkpoint
+		if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&decoded); err != nil {
+			return fmt.Errorf("failed to decode checkpoint for %q: %w", path, err)
+		}
+		cp = &decoded
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return cp, cp != nil, nil
+}
+
+func (s *boltIndexStore) SaveCheckpoint(cp *Checkpoint) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cp); err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketCheckpoints).Put([]byte(cp.Path), buf.Bytes())
+	})
+}
+
+func (s *boltIndexStore) Close() error {
+	return s.db.Close()
+}
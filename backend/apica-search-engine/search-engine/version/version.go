@@ -0,0 +1,4 @@
+package version
+
+// Version is the build version, set via -ldflags at release build time.
+var Version = "dev"